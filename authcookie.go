@@ -2,15 +2,17 @@
 // authentication cookies.
 //
 // Cookie is a Base64 encoded (using URLEncoding, from RFC 4648) string, which
-// consists of concatenation of expiration time, login, and signature:
+// consists of concatenation of a header, login, and signature:
 //
-// 	expiration time || login || signature
+//	header || login || signature
 //
-// where expiration time is the number of seconds since Unix epoch UTC
-// indicating when this cookie must expire (4 bytes, big-endian, uint32), login
-// is a byte string of arbitrary length (at least 1 byte, not null-terminated),
-// and signature is 32 bytes of HMAC-SHA256(expiration_time || login, k), where
-// k = HMAC-SHA256(expiration_time || login, secret key).
+// where header carries the expiration time indicating when this cookie must
+// expire — either a bare 4-byte big-endian uint32 of seconds since the Unix
+// epoch UTC (v1), or a 1-byte version tag followed by an 8-byte big-endian
+// int64 of seconds since the Unix epoch UTC (v2; see version.go) — login is
+// a byte string of arbitrary length (at least 1 byte, not null-terminated),
+// and signature is 32 bytes of HMAC-SHA256(header || login, k), where
+// k = HMAC-SHA256(header || login, secret key).
 //
 // Example:
 //
@@ -22,7 +24,7 @@
 //	// cookie is now:
 //	// Tajh02JlbmRlcskYMxowgwPj5QZ94jaxhDoh3n0Yp4hgGtUpeO0YbMTY
 //	// send it to user's browser..
-//	
+//
 //	// To authenticate a user later, receive cookie and:
 //	login := authcookie.Login(cookie, secret)
 //	if login != "" {
@@ -36,15 +38,21 @@
 package authcookie
 
 import (
+	"crypto/aes"
+	"crypto/cipher"
 	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
 	"crypto/subtle"
 	"encoding/base64"
-	"encoding/binary"
-	"os"
+	"errors"
+	"io"
 	"time"
+
+	"golang.org/x/crypto/hkdf"
 )
 
-const decodedMinLength = 4 /*expiration*/ + 1 /*login*/ + 32 /*signature*/
+const decodedMinLength = 4 /*v1 header*/ + 1 /*login*/ + 32 /*signature*/
 
 // MinLength is the minimum allowed length of cookie string.
 //
@@ -54,34 +62,35 @@ const decodedMinLength = 4 /*expiration*/ + 1 /*login*/ + 32 /*signature*/
 var MinLength = base64.URLEncoding.EncodedLen(decodedMinLength)
 
 func getSignature(b []byte, secret []byte) []byte {
-	keym := hmac.NewSHA256(secret)
+	keym := hmac.New(sha256.New, secret)
 	keym.Write(b)
-	m := hmac.NewSHA256(keym.Sum())
+	m := hmac.New(sha256.New, keym.Sum(nil))
 	m.Write(b)
-	return m.Sum()
+	return m.Sum(nil)
 }
 
 var (
-	ErrMalformedCookie = os.NewError("malformed cookie")
-	ErrWrongSignature  = os.NewError("wrong cookie signature")
+	ErrMalformedCookie = errors.New("authcookie: malformed cookie")
+	ErrWrongSignature  = errors.New("authcookie: wrong cookie signature")
 )
 
 // New returns a signed authentication cookie for the given login,
 // expiration time in seconds since Unix epoch UTC, and secret key.
 // If the login is empty, the function returns an empty string.
+//
+// The cookie is emitted in the v2 wire format, unless EmitV1 is set.
 func New(login string, expires int64, secret []byte) string {
 	if login == "" {
 		return ""
 	}
+	header := encodeHeader(expires)
 	llen := len(login)
-	b := make([]byte, llen+4+32)
-	// Put expiration time.
-	binary.BigEndian.PutUint32(b, uint32(expires))
-	// Put login.
-	copy(b[4:], []byte(login))
+	b := make([]byte, len(header)+llen+32)
+	copy(b, header)
+	copy(b[len(header):], login)
 	// Calculate and put signature.
-	sig := getSignature([]byte(b[:4+llen]), secret)
-	copy(b[4+llen:], sig)
+	sig := getSignature(b[:len(header)+llen], secret)
+	copy(b[len(header)+llen:], sig)
 	// Base64-encode.
 	cookie := make([]byte, base64.URLEncoding.EncodedLen(len(b)))
 	base64.URLEncoding.Encode(cookie, b)
@@ -91,20 +100,21 @@ func New(login string, expires int64, secret []byte) string {
 // NewSinceNow returns a signed authetication cookie for the given login,
 // expiration time in seconds since current time, and secret key.
 func NewSinceNow(login string, sec int64, secret []byte) string {
-	return New(login, sec+time.Seconds(), secret)
+	return New(login, sec+time.Now().Unix(), secret)
 }
 
 // Parse verifies the given cookie with the secret key and returns login and
 // expiration time extracted from the cookie. If the cookie fails verification
 // or is not well-formed, the function returns an error.
 //
-// Callers must: 
+// Parse accepts cookies in both the v1 and v2 wire formats (see version.go).
+//
+// Callers must:
 //
 // 1. Check for the returned error and deny access if it's present.
 //
 // 2. Check the returned expiration time and deny access if it's in the past.
-//
-func Parse(cookie string, secret []byte) (login string, expires int64, err os.Error) {
+func Parse(cookie string, secret []byte) (login string, expires int64, err error) {
 	blen := base64.URLEncoding.DecodedLen(len(cookie))
 	// Avoid allocation if cookie is too short.
 	if blen < decodedMinLength {
@@ -132,8 +142,12 @@ func Parse(cookie string, secret []byte) (login string, expires int64, err os.Er
 		err = ErrWrongSignature
 		return
 	}
-	expires = int64(binary.BigEndian.Uint32(data[:4]))
-	login = string(data[4:])
+	var headerLen int
+	expires, headerLen, err = decodeHeader(data)
+	if err != nil {
+		return
+	}
+	login = string(data[headerLen:])
 	return
 }
 
@@ -142,7 +156,160 @@ func Parse(cookie string, secret []byte) (login string, expires int64, err os.Er
 // the function returns an empty string.
 func Login(cookie string, secret []byte) string {
 	l, exp, err := Parse(cookie, secret)
-	if err != nil || exp < time.Seconds() {
+	if err != nil || exp < time.Now().Unix() {
+		return ""
+	}
+	return l
+}
+
+// deriveBlockKey derives a 32-byte AES key from secret using HKDF-SHA256,
+// for callers of the Encrypted functions who don't supply their own block
+// key. Using a key derived from secret (instead of secret itself) keeps the
+// signing key and the encryption key cryptographically independent.
+func deriveBlockKey(secret []byte) []byte {
+	key := make([]byte, 32)
+	r := hkdf.New(sha256.New, secret, nil, []byte("authcookie encrypted cookie block key"))
+	if _, err := io.ReadFull(r, key); err != nil {
+		panic("authcookie: hkdf failed: " + err.Error())
+	}
+	return key
+}
+
+// sealBytes encrypts data with AES-GCM under blockKey, returning
+// nonce || ciphertext.
+func sealBytes(data []byte, blockKey []byte) ([]byte, error) {
+	block, err := aes.NewCipher(blockKey)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return nil, err
+	}
+	return gcm.Seal(nonce, nonce, data, nil), nil
+}
+
+// openBytes decrypts the nonce || ciphertext produced by sealBytes.
+func openBytes(sealed []byte, blockKey []byte) ([]byte, error) {
+	block, err := aes.NewCipher(blockKey)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < gcm.NonceSize() {
+		return nil, ErrMalformedCookie
+	}
+	nonce, ciphertext := sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():]
+	data, err := gcm.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return nil, ErrWrongSignature
+	}
+	return data, nil
+}
+
+// sealLogin encrypts login with AES-GCM under blockKey, returning
+// nonce || ciphertext.
+func sealLogin(login string, blockKey []byte) ([]byte, error) {
+	return sealBytes([]byte(login), blockKey)
+}
+
+// openLogin decrypts the nonce || ciphertext produced by sealLogin.
+func openLogin(sealed []byte, blockKey []byte) (string, error) {
+	data, err := openBytes(sealed, blockKey)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// NewEncrypted is like New, but encrypts login with AES-GCM under blockKey
+// before signing, so that the login is opaque to whoever holds the cookie.
+// The cookie layout is unchanged — expiration time, encrypted login, and
+// signature — except that the login field now contains a random nonce
+// followed by the AES-GCM ciphertext, and the expiration time is still left
+// unencrypted so Parse-like code can reject expired cookies without
+// decrypting them.
+//
+// If blockKey is nil, it is derived from secret via HKDF-SHA256, so callers
+// who only have a single secret can still use NewEncrypted.
+func NewEncrypted(login string, expires int64, secret, blockKey []byte) (string, error) {
+	if login == "" {
+		return "", nil
+	}
+	if blockKey == nil {
+		blockKey = deriveBlockKey(secret)
+	}
+	sealed, err := sealLogin(login, blockKey)
+	if err != nil {
+		return "", err
+	}
+	header := encodeHeader(expires)
+	b := make([]byte, len(header)+len(sealed)+32)
+	copy(b, header)
+	copy(b[len(header):], sealed)
+	sig := getSignature(b[:len(header)+len(sealed)], secret)
+	copy(b[len(header)+len(sealed):], sig)
+	cookie := make([]byte, base64.URLEncoding.EncodedLen(len(b)))
+	base64.URLEncoding.Encode(cookie, b)
+	return string(cookie), nil
+}
+
+// ParseEncrypted verifies and decrypts a cookie created by NewEncrypted,
+// returning the login and expiration time. If blockKey is nil, it is
+// derived from secret the same way NewEncrypted does. Like Parse, it
+// accepts cookies in both the v1 and v2 wire formats (see version.go).
+func ParseEncrypted(cookie string, secret, blockKey []byte) (login string, expires int64, err error) {
+	blen := base64.URLEncoding.DecodedLen(len(cookie))
+	if blen < decodedMinLength {
+		err = ErrMalformedCookie
+		return
+	}
+	b := make([]byte, blen)
+	blen, err = base64.URLEncoding.Decode(b, []byte(cookie))
+	if err != nil {
+		return
+	}
+	if blen < decodedMinLength {
+		err = ErrMalformedCookie
+		return
+	}
+	b = b[:blen]
+
+	sig := b[blen-32:]
+	data := b[:blen-32]
+
+	realSig := getSignature(data, secret)
+	if subtle.ConstantTimeCompare(realSig, sig) != 1 {
+		err = ErrWrongSignature
+		return
+	}
+	var headerLen int
+	expires, headerLen, err = decodeHeader(data)
+	if err != nil {
+		return
+	}
+
+	if blockKey == nil {
+		blockKey = deriveBlockKey(secret)
+	}
+	login, err = openLogin(data[headerLen:], blockKey)
+	return
+}
+
+// LoginEncrypted returns a valid login extracted from the given encrypted
+// cookie and verified using the given secret and block key. If verification
+// or decryption fails, or the cookie expired, the function returns an empty
+// string.
+func LoginEncrypted(cookie string, secret, blockKey []byte) string {
+	l, exp, err := ParseEncrypted(cookie, secret, blockKey)
+	if err != nil || exp < time.Now().Unix() {
 		return ""
 	}
 	return l
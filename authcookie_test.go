@@ -1,17 +1,24 @@
 package authcookie
 
 import (
+	"encoding/base64"
 	"testing"
 	"time"
 )
 
 func TestNew(t *testing.T) {
 	secret := []byte("secret key")
+
+	// v1 golden vector: predates the version tag and 64-bit expiration
+	// added for v2 (see version.go).
+	EmitV1 = true
 	good := "AAAAKmhlbGxvIHdvcmxk9p6koQvSacAeliAm445i7errSk1NPkYJGYZhF93wG9U="
 	c := New("hello world", 42, secret)
+	EmitV1 = false
 	if c != good {
 		t.Errorf("expected %q, got %q", good, c)
 	}
+
 	// Test empty login
 	c = New("", 42, secret)
 	if c != "" {
@@ -21,7 +28,7 @@ func TestNew(t *testing.T) {
 
 func TestParse(t *testing.T) {
 	// good
-	sec := time.Seconds()
+	sec := time.Now().Unix()
 	login := "bender"
 	key := []byte("another secret key")
 	c := New(login, sec, key)
@@ -53,11 +60,10 @@ func TestParse(t *testing.T) {
 	}
 }
 
-
 func TestLogin(t *testing.T) {
 	login := "~~~!|zoidberg|!~~~"
-	key := []byte("(:â‚¬")
-	exp := time.Seconds() + 120
+	key := []byte("(:€")
+	exp := time.Now().Unix() + 120
 	c := New(login, exp, key)
 	l := Login(c, key)
 	if l != login {
@@ -68,10 +74,86 @@ func TestLogin(t *testing.T) {
 	if l != "" {
 		t.Errorf("login expected empty string, got %q", l)
 	}
-	exp = time.Seconds() - 30
+	exp = time.Now().Unix() - 30
 	c = New(login, exp, key)
 	l = Login(c, key)
 	if l != "" {
 		t.Errorf("returned login from expired cookie")
 	}
 }
+
+func TestNewEncryptedParseEncrypted(t *testing.T) {
+	secret := []byte("secret key")
+	login := "bender"
+	sec := time.Now().Unix() + 120
+
+	c, err := NewEncrypted(login, sec, secret, nil)
+	if err != nil {
+		t.Fatalf("NewEncrypted: %s", err)
+	}
+	if c == "" {
+		t.Fatal("NewEncrypted returned empty cookie")
+	}
+
+	l, e, err := ParseEncrypted(c, secret, nil)
+	if err != nil {
+		t.Fatalf("error parsing valid encrypted cookie: %s", err)
+	}
+	if l != login {
+		t.Errorf("login: expected %q, got %q", login, l)
+	}
+	if e != sec {
+		t.Errorf("expiration: expected %d, got %d", sec, e)
+	}
+
+	// login must not be visible in the encoded cookie, nor once it is
+	// base64-decoded.
+	raw, _ := base64.URLEncoding.DecodeString(c)
+	for _, s := range []string{c, string(raw)} {
+		if containsLogin(s, login) {
+			t.Errorf("login leaked into encrypted cookie: %q", s)
+		}
+	}
+
+	// wrong secret fails verification before decryption is attempted.
+	if _, _, err := ParseEncrypted(c, []byte("wrong secret"), nil); err != ErrWrongSignature {
+		t.Errorf("expected ErrWrongSignature, got %v", err)
+	}
+
+	// wrong block key fails decryption even with the right secret.
+	if _, _, err := ParseEncrypted(c, secret, []byte("0123456789abcdef0123456789abcdef")); err == nil {
+		t.Error("ParseEncrypted succeeded with wrong block key")
+	}
+}
+
+func TestLoginEncrypted(t *testing.T) {
+	secret := []byte("secret key")
+	login := "zoidberg"
+	exp := time.Now().Unix() + 120
+
+	c, err := NewEncrypted(login, exp, secret, nil)
+	if err != nil {
+		t.Fatalf("NewEncrypted: %s", err)
+	}
+	if l := LoginEncrypted(c, secret, nil); l != login {
+		t.Errorf("login: expected %q, got %q", login, l)
+	}
+
+	exp = time.Now().Unix() - 30
+	c, err = NewEncrypted(login, exp, secret, nil)
+	if err != nil {
+		t.Fatalf("NewEncrypted: %s", err)
+	}
+	if l := LoginEncrypted(c, secret, nil); l != "" {
+		t.Errorf("returned login from expired encrypted cookie")
+	}
+}
+
+func containsLogin(cookie, login string) bool {
+	for i := 0; i+len(login) <= len(cookie); i++ {
+		if cookie[i:i+len(login)] == login {
+			return true
+		}
+	}
+	return false
+}
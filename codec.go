@@ -0,0 +1,216 @@
+package authcookie
+
+import (
+	"bytes"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"time"
+)
+
+// Codec signs and optionally encrypts values under a name, analogous to
+// gorilla/securecookie's Codec interface. SecureCookie is the only
+// implementation provided by this package.
+type Codec interface {
+	Encode(name string, value interface{}) (string, error)
+	Decode(name, value string, dst interface{}) error
+}
+
+// Serializer turns arbitrary Go values into bytes and back, so that
+// SecureCookie can sign and encrypt them. JSONSerializer and GobSerializer
+// are provided; JSONSerializer is the default.
+type Serializer interface {
+	Serialize(src interface{}) ([]byte, error)
+	Deserialize(data []byte, dst interface{}) error
+}
+
+// JSONSerializer serializes values with encoding/json.
+type JSONSerializer struct{}
+
+func (JSONSerializer) Serialize(src interface{}) ([]byte, error) {
+	return json.Marshal(src)
+}
+
+func (JSONSerializer) Deserialize(data []byte, dst interface{}) error {
+	return json.Unmarshal(data, dst)
+}
+
+// GobSerializer serializes values with encoding/gob.
+type GobSerializer struct{}
+
+func (GobSerializer) Serialize(src interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(src); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (GobSerializer) Deserialize(data []byte, dst interface{}) error {
+	return gob.NewDecoder(bytes.NewReader(data)).Decode(dst)
+}
+
+var (
+	// ErrValueTooLong is returned by Decode when the encoded cookie is
+	// longer than the SecureCookie's MaxLength.
+	ErrValueTooLong = errors.New("authcookie: encoded value too long")
+	// ErrValueTooOld is returned by Decode when the cookie's creation
+	// time is older than the SecureCookie's MaxAge.
+	ErrValueTooOld = errors.New("authcookie: encoded value too old")
+	// ErrValueTooNew is returned by Decode when the cookie's creation
+	// time is more recent than the SecureCookie's MinAge allows.
+	ErrValueTooNew = errors.New("authcookie: encoded value too new")
+)
+
+// SecureCookie signs, and optionally encrypts, arbitrary Go values, unlike
+// the package-level New/Parse/Login, which only handle a login string.
+//
+// Use NewSecureCookie to construct one.
+type SecureCookie struct {
+	hashKey    []byte
+	blockKey   []byte
+	maxAge     time.Duration
+	minAge     time.Duration
+	maxLength  int
+	serializer Serializer
+}
+
+// NewSecureCookie returns a new SecureCookie that signs values with hashKey.
+// If blockKey is not nil, values are also AES-GCM encrypted under it.
+// The default serializer is JSONSerializer.
+func NewSecureCookie(hashKey, blockKey []byte) *SecureCookie {
+	return &SecureCookie{
+		hashKey:    hashKey,
+		blockKey:   blockKey,
+		serializer: JSONSerializer{},
+	}
+}
+
+// MaxAge sets the maximum age, in seconds, that Decode will accept; zero
+// (the default) disables the check.
+func (s *SecureCookie) MaxAge(age int64) *SecureCookie {
+	s.maxAge = time.Duration(age) * time.Second
+	return s
+}
+
+// MinAge sets the minimum age, in seconds, that Decode will accept,
+// rejecting values created too recently (an anti-replay window); zero (the
+// default) disables the check.
+func (s *SecureCookie) MinAge(age int64) *SecureCookie {
+	s.minAge = time.Duration(age) * time.Second
+	return s
+}
+
+// MaxLength sets the maximum allowed length of an encoded cookie, to avoid
+// decoding attacker-supplied values of unbounded size; zero (the default)
+// disables the check.
+func (s *SecureCookie) MaxLength(length int) *SecureCookie {
+	s.maxLength = length
+	return s
+}
+
+// SetSerializer sets the Serializer used to turn values into bytes before
+// signing (and, if configured, encryption). The default is JSONSerializer.
+func (s *SecureCookie) SetSerializer(sz Serializer) *SecureCookie {
+	s.serializer = sz
+	return s
+}
+
+// macData returns the bytes that are HMAC-signed: the cookie name
+// length-prefixed with a fixed-width 8-byte big-endian count (so names
+// can't be confused with each other or with the data that follows), then
+// the name, then data itself. Mixing the name into the MAC prevents a
+// value encoded for one cookie name from being replayed under another; a
+// single-byte length prefix would let two names of 256 or more bytes that
+// agree mod 256 collide, so the prefix is widened to 8 bytes, matching the
+// timestamp prefix Encode writes just after it.
+func macData(name string, data []byte) []byte {
+	b := make([]byte, 8+len(name)+len(data))
+	binary.BigEndian.PutUint64(b, uint64(len(name)))
+	copy(b[8:], name)
+	copy(b[8+len(name):], data)
+	return b
+}
+
+// Encode serializes value, optionally encrypts it, signs the result, and
+// returns a base64url-encoded cookie value. name is not stored in the
+// cookie; it is mixed into the signature so a cookie encoded for one name
+// cannot be decoded under another.
+func (s *SecureCookie) Encode(name string, value interface{}) (string, error) {
+	payload, err := s.serializer.Serialize(value)
+	if err != nil {
+		return "", err
+	}
+	if s.blockKey != nil {
+		payload, err = sealBytes(payload, s.blockKey)
+		if err != nil {
+			return "", err
+		}
+	}
+	data := make([]byte, 8+len(payload))
+	binary.BigEndian.PutUint64(data, uint64(time.Now().Unix()))
+	copy(data[8:], payload)
+
+	sig := getSignature(macData(name, data), s.hashKey)
+
+	b := append(data, sig...)
+	cookie := make([]byte, base64.URLEncoding.EncodedLen(len(b)))
+	base64.URLEncoding.Encode(cookie, b)
+	return string(cookie), nil
+}
+
+// Decode verifies and decodes a cookie produced by Encode for the same
+// name into dst, which must be a pointer. It enforces MaxLength, MaxAge,
+// and MinAge, if set.
+func (s *SecureCookie) Decode(name, value string, dst interface{}) error {
+	if s.maxLength > 0 && len(value) > s.maxLength {
+		return ErrValueTooLong
+	}
+	if reflect.ValueOf(dst).Kind() != reflect.Ptr {
+		return errors.New("authcookie: dst must be a pointer")
+	}
+
+	blen := base64.URLEncoding.DecodedLen(len(value))
+	if blen < 8+32 {
+		return ErrMalformedCookie
+	}
+	b := make([]byte, blen)
+	blen, err := base64.URLEncoding.Decode(b, []byte(value))
+	if err != nil {
+		return err
+	}
+	if blen < 8+32 {
+		return ErrMalformedCookie
+	}
+	b = b[:blen]
+
+	sig := b[blen-32:]
+	data := b[:blen-32]
+
+	realSig := getSignature(macData(name, data), s.hashKey)
+	if subtle.ConstantTimeCompare(realSig, sig) != 1 {
+		return ErrWrongSignature
+	}
+
+	created := int64(binary.BigEndian.Uint64(data[:8]))
+	now := time.Now().Unix()
+	if s.maxAge > 0 && now-created > int64(s.maxAge/time.Second) {
+		return ErrValueTooOld
+	}
+	if s.minAge > 0 && now-created < int64(s.minAge/time.Second) {
+		return ErrValueTooNew
+	}
+
+	payload := data[8:]
+	if s.blockKey != nil {
+		payload, err = openBytes(payload, s.blockKey)
+		if err != nil {
+			return err
+		}
+	}
+	return s.serializer.Deserialize(payload, dst)
+}
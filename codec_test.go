@@ -0,0 +1,108 @@
+package authcookie
+
+import (
+	"testing"
+)
+
+type testValue struct {
+	Name string
+	Age  int
+}
+
+func TestSecureCookieEncodeDecode(t *testing.T) {
+	s := NewSecureCookie([]byte("hash key"), nil)
+	v := testValue{Name: "bender", Age: 4}
+
+	encoded, err := s.Encode("session", v)
+	if err != nil {
+		t.Fatalf("Encode: %s", err)
+	}
+
+	var got testValue
+	if err := s.Decode("session", encoded, &got); err != nil {
+		t.Fatalf("Decode: %s", err)
+	}
+	if got != v {
+		t.Errorf("expected %+v, got %+v", v, got)
+	}
+
+	// wrong name must fail, since the name is mixed into the signature.
+	if err := s.Decode("other", encoded, &got); err == nil {
+		t.Error("Decode succeeded with wrong name")
+	}
+}
+
+func TestSecureCookieEncrypted(t *testing.T) {
+	s := NewSecureCookie([]byte("hash key"), []byte("0123456789abcdef0123456789abcdef"))
+	v := map[string]string{"login": "zoidberg"}
+
+	encoded, err := s.Encode("session", v)
+	if err != nil {
+		t.Fatalf("Encode: %s", err)
+	}
+
+	var got map[string]string
+	if err := s.Decode("session", encoded, &got); err != nil {
+		t.Fatalf("Decode: %s", err)
+	}
+	if got["login"] != v["login"] {
+		t.Errorf("expected %+v, got %+v", v, got)
+	}
+}
+
+func TestSecureCookieGob(t *testing.T) {
+	s := NewSecureCookie([]byte("hash key"), nil).SetSerializer(GobSerializer{})
+	v := testValue{Name: "hermes", Age: 195}
+
+	encoded, err := s.Encode("session", v)
+	if err != nil {
+		t.Fatalf("Encode: %s", err)
+	}
+
+	var got testValue
+	if err := s.Decode("session", encoded, &got); err != nil {
+		t.Fatalf("Decode: %s", err)
+	}
+	if got != v {
+		t.Errorf("expected %+v, got %+v", v, got)
+	}
+}
+
+func TestSecureCookieLongNamesDoNotCollide(t *testing.T) {
+	s := NewSecureCookie([]byte("hash key"), nil)
+	v := testValue{Name: "bender", Age: 4}
+
+	// Two names whose lengths agree mod 256 would collide under a
+	// single-byte length prefix, producing identical macData and letting
+	// a cookie encoded for one decode under the other.
+	short := string(make([]byte, 4))
+	long := string(make([]byte, 256+4))
+
+	encoded, err := s.Encode(long, v)
+	if err != nil {
+		t.Fatalf("Encode: %s", err)
+	}
+
+	var got testValue
+	if err := s.Decode(short, encoded, &got); err == nil {
+		t.Error("Decode succeeded under a different name whose length agrees mod 256")
+	}
+	if err := s.Decode(long, encoded, &got); err != nil {
+		t.Fatalf("Decode with the original long name: %s", err)
+	}
+	if got != v {
+		t.Errorf("expected %+v, got %+v", v, got)
+	}
+}
+
+func TestSecureCookieMaxLength(t *testing.T) {
+	s := NewSecureCookie([]byte("hash key"), nil).MaxLength(10)
+	encoded, err := s.Encode("session", testValue{Name: "fry", Age: 25})
+	if err != nil {
+		t.Fatalf("Encode: %s", err)
+	}
+	var got testValue
+	if err := s.Decode("session", encoded, &got); err != ErrValueTooLong {
+		t.Errorf("expected ErrValueTooLong, got %v", err)
+	}
+}
@@ -0,0 +1,123 @@
+package authcookie
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+	"time"
+)
+
+var (
+	// ErrTooNew is returned by Cookie.Decode when the cookie was created
+	// less than MinAge ago.
+	ErrTooNew = errors.New("authcookie: cookie is too new")
+	// ErrExpired is returned by Cookie.Decode when the cookie's
+	// expiration time is in the past, or its age exceeds MaxAge.
+	ErrExpired = errors.New("authcookie: cookie has expired")
+	// ErrTooLong is returned by Cookie.Decode when the encoded cookie
+	// exceeds MaxLength.
+	ErrTooLong = errors.New("authcookie: cookie is too long")
+)
+
+// Cookie wraps the package-level New/Parse (or NewEncrypted/ParseEncrypted,
+// if BlockKey is set) with server-imposed freshness and size policies, so
+// that code using the plain string API doesn't have to hand-check the
+// expiration time returned by Parse or bound the cookie length itself.
+// Encode prepends a creation timestamp to the login before signing it, so
+// that Decode's MaxAge and MinAge checks are based on the cookie's actual
+// age rather than the expiration time baked in at encoding time.
+//
+// MaxAge, if non-zero, is the validity period Encode bakes into new
+// cookies (the embedded expiration is creation time + MaxAge) and,
+// independently, the upper bound Decode enforces on the cookie's age —
+// so shortening MaxAge on a running server immediately tightens the
+// effective lifetime of cookies issued earlier with a longer one, even
+// though their embedded expiration is unchanged. MinAge, if non-zero,
+// rejects cookies decoded less than MinAge after they were created, as an
+// anti-replay window; unlike MaxAge, it does not bear on the embedded
+// expiration, and does not require MaxAge to also be set. MaxLength, if
+// non-zero, rejects encoded cookies longer than it before they are even
+// parsed.
+type Cookie struct {
+	Secret    []byte
+	BlockKey  []byte // optional; if set, Encode/Decode use the encrypted wire format
+	MinAge    time.Duration
+	MaxAge    time.Duration
+	MaxLength int
+}
+
+// encodeCreated prepends an 8-byte big-endian creation timestamp to login,
+// so Decode can recover the cookie's age independent of its MaxAge at
+// encoding time.
+func encodeCreated(created int64, login string) string {
+	b := make([]byte, 8+len(login))
+	binary.BigEndian.PutUint64(b, uint64(created))
+	copy(b[8:], login)
+	return string(b)
+}
+
+// decodeCreated splits a payload produced by encodeCreated back into its
+// creation timestamp and login.
+func decodeCreated(payload string) (created int64, login string, err error) {
+	if len(payload) < 8 {
+		return 0, "", ErrMalformedCookie
+	}
+	created = int64(binary.BigEndian.Uint64([]byte(payload[:8])))
+	return created, payload[8:], nil
+}
+
+// Encode returns a signed (and, if BlockKey is set, encrypted) cookie for
+// login, valid for MaxAge (or forever, if MaxAge is zero — the embedded
+// expiration is then the maximum int64, which Decode's expiration check
+// never rejects). If login is empty, it returns an empty string.
+func (c *Cookie) Encode(login string) (string, error) {
+	if login == "" {
+		return "", nil
+	}
+	created := time.Now().Unix()
+	expires := int64(math.MaxInt64)
+	if c.MaxAge > 0 {
+		expires = created + int64(c.MaxAge/time.Second)
+	}
+	payload := encodeCreated(created, login)
+	if c.BlockKey != nil {
+		return NewEncrypted(payload, expires, c.Secret, c.BlockKey)
+	}
+	return New(payload, expires, c.Secret), nil
+}
+
+// Decode verifies cookie and returns its login and expiration time,
+// enforcing MaxLength, MaxAge, and MinAge in addition to the signature
+// check performed by Parse/ParseEncrypted.
+func (c *Cookie) Decode(cookie string) (login string, expires int64, err error) {
+	if c.MaxLength > 0 && len(cookie) > c.MaxLength {
+		return "", 0, ErrTooLong
+	}
+
+	var payload string
+	if c.BlockKey != nil {
+		payload, expires, err = ParseEncrypted(cookie, c.Secret, c.BlockKey)
+	} else {
+		payload, expires, err = Parse(cookie, c.Secret)
+	}
+	if err != nil {
+		return "", 0, err
+	}
+	created, login, err := decodeCreated(payload)
+	if err != nil {
+		return "", expires, err
+	}
+
+	now := time.Now().Unix()
+	if expires < now {
+		return "", expires, ErrExpired
+	}
+	age := now - created
+	if c.MaxAge > 0 && age > int64(c.MaxAge/time.Second) {
+		return "", expires, ErrExpired
+	}
+	if c.MinAge > 0 && age < int64(c.MinAge/time.Second) {
+		return "", expires, ErrTooNew
+	}
+	return login, expires, nil
+}
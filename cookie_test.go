@@ -0,0 +1,139 @@
+package authcookie
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCookieEncodeDecode(t *testing.T) {
+	c := &Cookie{Secret: []byte("secret key"), MaxAge: time.Hour}
+
+	encoded, err := c.Encode("bender")
+	if err != nil {
+		t.Fatalf("Encode: %s", err)
+	}
+	login, _, err := c.Decode(encoded)
+	if err != nil {
+		t.Fatalf("Decode: %s", err)
+	}
+	if login != "bender" {
+		t.Errorf("login: expected %q, got %q", "bender", login)
+	}
+}
+
+func TestCookieExpired(t *testing.T) {
+	c := &Cookie{Secret: []byte("secret key")}
+	payload := encodeCreated(time.Now().Unix(), "bender")
+	expired := New(payload, time.Now().Unix()-30, c.Secret)
+	if _, _, err := c.Decode(expired); err != ErrExpired {
+		t.Errorf("expected ErrExpired, got %v", err)
+	}
+}
+
+func TestCookieTooNew(t *testing.T) {
+	c := &Cookie{Secret: []byte("secret key"), MaxAge: time.Hour, MinAge: 10 * time.Minute}
+
+	fresh, err := c.Encode("bender")
+	if err != nil {
+		t.Fatalf("Encode: %s", err)
+	}
+	if _, _, err := c.Decode(fresh); err != ErrTooNew {
+		t.Errorf("expected ErrTooNew for freshly issued cookie, got %v", err)
+	}
+
+	// A cookie created more than MinAge ago must be accepted.
+	created := time.Now().Add(-20 * time.Minute).Unix()
+	payload := encodeCreated(created, "bender")
+	seasoned := New(payload, created+int64(time.Hour/time.Second), c.Secret)
+	if _, _, err := c.Decode(seasoned); err != nil {
+		t.Errorf("unexpected error for seasoned cookie: %v", err)
+	}
+}
+
+func TestCookieMinAgeWithoutMaxAge(t *testing.T) {
+	c := &Cookie{Secret: []byte("secret key"), MinAge: 10 * time.Minute}
+
+	fresh, err := c.Encode("bender")
+	if err != nil {
+		t.Fatalf("Encode: %s", err)
+	}
+	if _, _, err := c.Decode(fresh); err != ErrTooNew {
+		t.Errorf("expected ErrTooNew for freshly issued cookie with only MinAge set, got %v", err)
+	}
+}
+
+func TestCookieMaxAgeEnforcedRegardlessOfEmbeddedExpiration(t *testing.T) {
+	secret := []byte("secret key")
+	// Issued by a server configured with a generous MaxAge, so the
+	// embedded expiration is far in the future...
+	created := time.Now().Add(-2 * time.Hour).Unix()
+	payload := encodeCreated(created, "bender")
+	cookie := New(payload, created+int64(24*time.Hour/time.Second), secret)
+
+	// ...but a server that has since shortened MaxAge must still reject
+	// it once its actual age exceeds the new limit.
+	c := &Cookie{Secret: secret, MaxAge: time.Hour}
+	if _, _, err := c.Decode(cookie); err != ErrExpired {
+		t.Errorf("expected ErrExpired once age exceeds the tightened MaxAge, got %v", err)
+	}
+}
+
+func TestCookieForever(t *testing.T) {
+	c := &Cookie{Secret: []byte("secret key")}
+
+	encoded, err := c.Encode("bender")
+	if err != nil {
+		t.Fatalf("Encode: %s", err)
+	}
+
+	time.Sleep(1100 * time.Millisecond)
+
+	login, _, err := c.Decode(encoded)
+	if err != nil {
+		t.Fatalf("Decode: %s", err)
+	}
+	if login != "bender" {
+		t.Errorf("login: expected %q, got %q", "bender", login)
+	}
+}
+
+func TestCookieTooLong(t *testing.T) {
+	c := &Cookie{Secret: []byte("secret key"), MaxLength: 10}
+	encoded, err := c.Encode("bender")
+	if err != nil {
+		t.Fatalf("Encode: %s", err)
+	}
+	if _, _, err := c.Decode(encoded); err != ErrTooLong {
+		t.Errorf("expected ErrTooLong, got %v", err)
+	}
+}
+
+func TestCookieEncrypted(t *testing.T) {
+	c := &Cookie{
+		Secret:   []byte("secret key"),
+		BlockKey: []byte("0123456789abcdef0123456789abcdef"),
+		MaxAge:   time.Hour,
+	}
+	encoded, err := c.Encode("hermes")
+	if err != nil {
+		t.Fatalf("Encode: %s", err)
+	}
+	login, _, err := c.Decode(encoded)
+	if err != nil {
+		t.Fatalf("Decode: %s", err)
+	}
+	if login != "hermes" {
+		t.Errorf("login: expected %q, got %q", "hermes", login)
+	}
+}
+
+func TestCookieEncodeEmptyLogin(t *testing.T) {
+	c := &Cookie{Secret: []byte("secret key"), MaxAge: time.Hour}
+	encoded, err := c.Encode("")
+	if err != nil {
+		t.Fatalf("Encode: %s", err)
+	}
+	if encoded != "" {
+		t.Errorf("expected empty cookie for empty login, got %q", encoded)
+	}
+}
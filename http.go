@@ -0,0 +1,143 @@
+package authcookie
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// Options controls how SetCookie, ReadCookie, and Middleware construct and
+// read the underlying http.Cookie, and how they use the Cookie type to sign
+// (and optionally encrypt and bound the freshness of) the cookie value.
+type Options struct {
+	// Name is the cookie name Middleware reads. SetCookie and ReadCookie
+	// take their name as an explicit argument instead, so it's only
+	// consulted by Middleware. An empty Name defaults to "auth".
+	Name string
+
+	// BlockKey, MinAge, and MaxLength are passed straight through to the
+	// underlying Cookie (see cookie.go). MaxAge is too, but only on the
+	// read side (ReadCookie/Middleware): SetCookie always signs the
+	// lifetime implied by its own expires argument, so the http.Cookie's
+	// Expires header and the signed expiration never disagree. MaxAge
+	// instead bounds how old a cookie ReadCookie/Middleware will accept,
+	// independent of what was signed at issuance.
+	BlockKey  []byte
+	MinAge    time.Duration
+	MaxAge    time.Duration
+	MaxLength int
+
+	Path     string
+	Domain   string
+	HttpOnly bool
+	Secure   bool
+	SameSite http.SameSite
+}
+
+func (o *Options) nameOrDefault() string {
+	if o == nil || o.Name == "" {
+		return "auth"
+	}
+	return o.Name
+}
+
+// cookie builds the Cookie that SetCookie/ReadCookie sign or verify with,
+// applying opts' policy fields (or their zero-value defaults, if opts is
+// nil). maxAge, if non-zero, wins over opts.MaxAge: SetCookie passes the
+// lifetime it's actually signing (derived from its own expires argument),
+// so the signed expiration always matches the http.Cookie's Expires.
+// ReadCookie and Middleware pass zero, so opts.MaxAge — if set — applies
+// as Decode's read-side freshness ceiling instead.
+func (o *Options) cookie(secret []byte, maxAge time.Duration) *Cookie {
+	c := &Cookie{Secret: secret, MaxAge: maxAge}
+	if o != nil {
+		c.BlockKey = o.BlockKey
+		c.MinAge = o.MinAge
+		c.MaxLength = o.MaxLength
+		if maxAge == 0 && o.MaxAge > 0 {
+			c.MaxAge = o.MaxAge
+		}
+	}
+	return c
+}
+
+// SetCookie signs login with secret, using opts' BlockKey/MinAge/MaxLength
+// policy (see Cookie), and sets it as an http.Cookie named name on w,
+// expiring at expires. If login is empty, no cookie is set. If opts is
+// nil, the cookie is set with Path "/" and HttpOnly true, and without
+// Domain, Secure, or SameSite.
+func SetCookie(w http.ResponseWriter, name, login string, expires time.Time, secret []byte, opts *Options) {
+	if login == "" {
+		return
+	}
+	value, err := opts.cookie(secret, time.Until(expires)).Encode(login)
+	if err != nil || value == "" {
+		return
+	}
+	hc := &http.Cookie{
+		Name:    name,
+		Value:   value,
+		Expires: expires,
+		Path:    "/",
+	}
+	if opts != nil {
+		if opts.Path != "" {
+			hc.Path = opts.Path
+		}
+		hc.Domain = opts.Domain
+		hc.HttpOnly = opts.HttpOnly
+		hc.Secure = opts.Secure
+		hc.SameSite = opts.SameSite
+	} else {
+		hc.HttpOnly = true
+	}
+	http.SetCookie(w, hc)
+}
+
+// ReadCookie reads the cookie named name from r, verifies it with secret
+// under opts' BlockKey/MinAge/MaxAge/MaxLength policy (see Cookie), and
+// returns its login. It returns an error if the cookie is missing, fails
+// verification, has expired, or fails opts' policy.
+func ReadCookie(r *http.Request, name string, secret []byte, opts *Options) (login string, err error) {
+	hc, err := r.Cookie(name)
+	if err != nil {
+		return "", err
+	}
+	login, _, err = opts.cookie(secret, 0).Decode(hc.Value)
+	if err != nil {
+		return "", err
+	}
+	return login, nil
+}
+
+type contextKey int
+
+// loginContextKey is the context key Middleware stores the verified login
+// under.
+const loginContextKey contextKey = 0
+
+// LoginFromContext returns the login injected by Middleware into the
+// request context, and whether one was present.
+func LoginFromContext(ctx context.Context) (string, bool) {
+	login, ok := ctx.Value(loginContextKey).(string)
+	return login, ok
+}
+
+// Middleware returns a middleware that verifies the cookie named opts.Name
+// (or "auth", if opts is nil or its Name is empty) using secret and opts'
+// policy, and, if it verifies, injects its login into the request context
+// under a key retrievable with LoginFromContext. Requests with a missing,
+// invalid, or expired cookie are passed through unmodified, with no login
+// in the context — it is up to the wrapped handler to decide whether that
+// means denying access.
+func Middleware(secret []byte, opts *Options) func(http.Handler) http.Handler {
+	name := opts.nameOrDefault()
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if login, err := ReadCookie(r, name, secret, opts); err == nil {
+				r = r.WithContext(context.WithValue(r.Context(), loginContextKey, login))
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
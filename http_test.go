@@ -0,0 +1,150 @@
+package authcookie
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestSetCookieReadCookie(t *testing.T) {
+	secret := []byte("secret key")
+	w := httptest.NewRecorder()
+	SetCookie(w, "auth", "bender", time.Now().Add(time.Hour), secret, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	for _, c := range w.Result().Cookies() {
+		req.AddCookie(c)
+	}
+
+	login, err := ReadCookie(req, "auth", secret, nil)
+	if err != nil {
+		t.Fatalf("ReadCookie: %s", err)
+	}
+	if login != "bender" {
+		t.Errorf("login: expected %q, got %q", "bender", login)
+	}
+}
+
+func TestReadCookieMissing(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	if _, err := ReadCookie(req, "auth", []byte("secret key"), nil); err == nil {
+		t.Error("expected error for missing cookie")
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	secret := []byte("secret key")
+
+	handler := Middleware(secret, nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		login, ok := LoginFromContext(r.Context())
+		if !ok {
+			t.Error("expected login in context")
+		}
+		if login != "fry" {
+			t.Errorf("login: expected %q, got %q", "fry", login)
+		}
+	}))
+
+	w := httptest.NewRecorder()
+	SetCookie(w, "auth", "fry", time.Now().Add(time.Hour), secret, nil)
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	for _, c := range w.Result().Cookies() {
+		req.AddCookie(c)
+	}
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+}
+
+func TestSetCookieEmptyLogin(t *testing.T) {
+	w := httptest.NewRecorder()
+	SetCookie(w, "auth", "", time.Now().Add(time.Hour), []byte("secret key"), nil)
+	if len(w.Result().Cookies()) != 0 {
+		t.Error("expected no cookie to be set for an empty login")
+	}
+}
+
+func TestSetCookieReadCookieEncrypted(t *testing.T) {
+	secret := []byte("secret key")
+	opts := &Options{BlockKey: []byte("0123456789abcdef0123456789abcdef")}
+
+	w := httptest.NewRecorder()
+	SetCookie(w, "auth", "bender", time.Now().Add(time.Hour), secret, opts)
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	for _, c := range w.Result().Cookies() {
+		req.AddCookie(c)
+		if containsLogin(c.Value, "bender") {
+			t.Errorf("login leaked into encrypted cookie value: %q", c.Value)
+		}
+	}
+
+	login, err := ReadCookie(req, "auth", secret, opts)
+	if err != nil {
+		t.Fatalf("ReadCookie: %s", err)
+	}
+	if login != "bender" {
+		t.Errorf("login: expected %q, got %q", "bender", login)
+	}
+}
+
+func TestSetCookieIgnoresOptionsMaxAge(t *testing.T) {
+	secret := []byte("secret key")
+	opts := &Options{MaxAge: time.Hour}
+
+	w := httptest.NewRecorder()
+	SetCookie(w, "auth", "bender", time.Now().Add(24*time.Hour), secret, opts)
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	for _, c := range w.Result().Cookies() {
+		req.AddCookie(c)
+	}
+
+	// opts.MaxAge must not shorten the signed expiration below the
+	// explicit expires SetCookie was given; only Decode's read-side
+	// freshness ceiling should consult it.
+	if _, err := ReadCookie(req, "auth", secret, nil); err != nil {
+		t.Fatalf("ReadCookie without opts.MaxAge: %s", err)
+	}
+	_, signed, err := (&Cookie{Secret: secret}).Decode(w.Result().Cookies()[0].Value)
+	if err != nil {
+		t.Fatalf("Decode: %s", err)
+	}
+	if signed < time.Now().Add(23*time.Hour).Unix() {
+		t.Errorf("signed expiration %d was shortened by opts.MaxAge", signed)
+	}
+}
+
+func TestReadCookieEnforcesOptionsMaxAge(t *testing.T) {
+	secret := []byte("secret key")
+
+	// Signed an hour ago with a generous embedded expiration, as if by a
+	// server that has since tightened its MaxAge policy.
+	created := time.Now().Add(-time.Hour).Unix()
+	payload := encodeCreated(created, "bender")
+	value := New(payload, created+int64(24*time.Hour/time.Second), secret)
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.AddCookie(&http.Cookie{Name: "auth", Value: value})
+
+	opts := &Options{MaxAge: time.Minute}
+	if _, err := ReadCookie(req, "auth", secret, opts); err != ErrExpired {
+		t.Errorf("expected ErrExpired once opts.MaxAge is exceeded, got %v", err)
+	}
+}
+
+func TestMiddlewareNoCookie(t *testing.T) {
+	called := false
+	handler := Middleware([]byte("secret key"), nil)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		called = true
+		if _, ok := LoginFromContext(r.Context()); ok {
+			t.Error("expected no login in context")
+		}
+	}))
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+	if !called {
+		t.Error("expected wrapped handler to be called")
+	}
+}
@@ -0,0 +1,79 @@
+package authcookie
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrNoKeys is returned by Keychain methods when the keychain is empty.
+var ErrNoKeys = errors.New("authcookie: keychain has no keys")
+
+// KeyPair holds a hash key used for signing and an optional block key used
+// for encrypting (see NewEncrypted) cookies issued under it.
+type KeyPair struct {
+	Hash  []byte
+	Block []byte // nil means the cookie is signed but not encrypted
+}
+
+// Keychain is an ordered list of KeyPairs that supports rotating signing
+// keys without invalidating outstanding cookies: New always signs with the
+// first KeyPair, while Parse and Login try every KeyPair in order, so
+// cookies issued under an older key keep verifying until they expire. This
+// lets callers replace the first KeyPair with a fresh one and redeploy,
+// then drop the oldest KeyPair once they're confident no outstanding
+// cookies still depend on it.
+type Keychain []KeyPair
+
+// New returns a signed (and, if the first KeyPair has a Block key,
+// encrypted) authentication cookie for login and expires, using the first
+// KeyPair in the keychain. If the keychain is empty, it returns "".
+func (kc Keychain) New(login string, expires int64) string {
+	if len(kc) == 0 {
+		return ""
+	}
+	kp := kc[0]
+	if kp.Block != nil {
+		cookie, err := NewEncrypted(login, expires, kp.Hash, kp.Block)
+		if err != nil {
+			return ""
+		}
+		return cookie
+	}
+	return New(login, expires, kp.Hash)
+}
+
+// Parse tries every KeyPair in the keychain, in order, to verify cookie,
+// returning the login, expiration time, and the index of the KeyPair that
+// matched. A non-zero keyIndex means the cookie was signed with an older
+// key and the caller may want to re-issue it under kc.New ("sliding
+// refresh") so it picks up the current key sooner rather than later.
+//
+// If no KeyPair verifies the cookie, Parse returns the error from the last
+// attempt (or ErrNoKeys if the keychain is empty).
+func (kc Keychain) Parse(cookie string) (login string, expires int64, keyIndex int, err error) {
+	if len(kc) == 0 {
+		return "", 0, -1, ErrNoKeys
+	}
+	for i, kp := range kc {
+		if kp.Block != nil {
+			login, expires, err = ParseEncrypted(cookie, kp.Hash, kp.Block)
+		} else {
+			login, expires, err = Parse(cookie, kp.Hash)
+		}
+		if err == nil {
+			return login, expires, i, nil
+		}
+	}
+	return "", 0, -1, err
+}
+
+// Login returns a valid login extracted from cookie and the index of the
+// KeyPair that verified it. If no key verifies the cookie, or it has
+// expired, Login returns an empty login and a keyIndex of -1.
+func (kc Keychain) Login(cookie string) (login string, keyIndex int) {
+	l, exp, idx, err := kc.Parse(cookie)
+	if err != nil || exp < time.Now().Unix() {
+		return "", -1
+	}
+	return l, idx
+}
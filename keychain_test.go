@@ -0,0 +1,79 @@
+package authcookie
+
+import (
+	"testing"
+	"time"
+)
+
+func TestKeychainRotation(t *testing.T) {
+	oldKey := KeyPair{Hash: []byte("old secret")}
+	newKey := KeyPair{Hash: []byte("new secret")}
+
+	// Cookie was issued under the old keychain, when oldKey was current.
+	oldChain := Keychain{oldKey}
+	exp := time.Now().Unix() + 120
+	cookie := oldChain.New("bender", exp)
+	if cookie == "" {
+		t.Fatal("Keychain.New returned empty cookie")
+	}
+
+	// After rotation, the new key is first, but the old key still verifies.
+	rotated := Keychain{newKey, oldKey}
+	login, expires, idx, err := rotated.Parse(cookie)
+	if err != nil {
+		t.Fatalf("Parse: %s", err)
+	}
+	if login != "bender" {
+		t.Errorf("login: expected %q, got %q", "bender", login)
+	}
+	if expires != exp {
+		t.Errorf("expiration: expected %d, got %d", exp, expires)
+	}
+	if idx != 1 {
+		t.Errorf("keyIndex: expected 1 (old key), got %d", idx)
+	}
+
+	// A cookie issued under the rotated keychain uses the new key, idx 0.
+	fresh := rotated.New("fry", exp)
+	_, _, idx, err = rotated.Parse(fresh)
+	if err != nil {
+		t.Fatalf("Parse: %s", err)
+	}
+	if idx != 0 {
+		t.Errorf("keyIndex: expected 0 (current key), got %d", idx)
+	}
+}
+
+func TestKeychainLogin(t *testing.T) {
+	kc := Keychain{{Hash: []byte("secret")}}
+	exp := time.Now().Unix() + 60
+	cookie := kc.New("zoidberg", exp)
+
+	login, idx := kc.Login(cookie)
+	if login != "zoidberg" || idx != 0 {
+		t.Errorf("expected (zoidberg, 0), got (%q, %d)", login, idx)
+	}
+
+	empty := Keychain{}
+	if login, idx := empty.Login(cookie); login != "" || idx != -1 {
+		t.Errorf("expected empty login for empty keychain, got (%q, %d)", login, idx)
+	}
+
+	if login, idx := kc.Login("garbage"); login != "" || idx != -1 {
+		t.Errorf("expected empty login for garbage cookie, got (%q, %d)", login, idx)
+	}
+}
+
+func TestKeychainEncrypted(t *testing.T) {
+	kc := Keychain{{Hash: []byte("secret"), Block: []byte("0123456789abcdef0123456789abcdef")}}
+	exp := time.Now().Unix() + 60
+	cookie := kc.New("hermes", exp)
+
+	login, _, idx, err := kc.Parse(cookie)
+	if err != nil {
+		t.Fatalf("Parse: %s", err)
+	}
+	if login != "hermes" || idx != 0 {
+		t.Errorf("expected (hermes, 0), got (%q, %d)", login, idx)
+	}
+}
@@ -0,0 +1,51 @@
+package authcookie
+
+import "encoding/binary"
+
+// Cookie wire format versions.
+//
+// v1 is the original, unversioned format: a bare 4-byte big-endian uint32
+// expiration time, which overflows in February 2106 and cannot represent an
+// expiration beyond it. v2 prepends a 1-byte version tag and widens the
+// expiration to an 8-byte big-endian int64.
+//
+// Parse and ParseEncrypted accept both: a leading tagV2 byte means the next
+// 8 bytes are the expiration; anything else is treated as a v1 cookie,
+// whose first 4 bytes are the expiration. A v1 cookie whose expiration lies
+// between 0x02000000 and 0x02FFFFFF seconds after the Unix epoch (in 1971)
+// starts with that byte too; if its login is at least 5 bytes long it is
+// misread as v2, yielding a wrong expiration and a truncated login. No real
+// cookie issued by this package has ever had such an expiration.
+const tagV2 = 2
+
+// EmitV1 makes New and NewEncrypted emit the legacy, unversioned v1 format
+// instead of v2, for deployments that need to keep issuing cookies readable
+// by older clients of this package during a transition period. New cookies
+// should be migrated off v1 well before 2038.
+var EmitV1 = false
+
+// encodeHeader returns the version tag (if any) and expiration time to
+// prepend to a cookie's signed data, according to EmitV1.
+func encodeHeader(expires int64) []byte {
+	if EmitV1 {
+		b := make([]byte, 4)
+		binary.BigEndian.PutUint32(b, uint32(expires))
+		return b
+	}
+	b := make([]byte, 1+8)
+	b[0] = tagV2
+	binary.BigEndian.PutUint64(b[1:], uint64(expires))
+	return b
+}
+
+// decodeHeader reads a v1 or v2 header from the start of data, returning
+// the expiration time and the number of bytes it occupied.
+func decodeHeader(data []byte) (expires int64, headerLen int, err error) {
+	if len(data) >= 1+8 && data[0] == tagV2 {
+		return int64(binary.BigEndian.Uint64(data[1:9])), 1 + 8, nil
+	}
+	if len(data) >= 4 {
+		return int64(binary.BigEndian.Uint32(data[:4])), 4, nil
+	}
+	return 0, 0, ErrMalformedCookie
+}
@@ -0,0 +1,57 @@
+package authcookie
+
+import (
+	"encoding/base64"
+	"testing"
+	"time"
+)
+
+func TestV2IsDefault(t *testing.T) {
+	secret := []byte("secret key")
+	exp := time.Now().Unix() + 120
+	c := New("bender", exp, secret)
+
+	if b, _ := base64.URLEncoding.DecodeString(c); len(b) == 0 || b[0] != tagV2 {
+		t.Errorf("expected cookie to start with the v2 tag: %q", c)
+	}
+
+	l, e, err := Parse(c, secret)
+	if err != nil {
+		t.Fatalf("Parse: %s", err)
+	}
+	if l != "bender" || e != exp {
+		t.Errorf("expected (bender, %d), got (%s, %d)", exp, l, e)
+	}
+}
+
+func TestV2SurvivesYear2038(t *testing.T) {
+	secret := []byte("secret key")
+	// 100 seconds past the v1 uint32 rollover.
+	exp := int64(1<<32) + 100
+	c := New("bender", exp, secret)
+
+	_, e, err := Parse(c, secret)
+	if err != nil {
+		t.Fatalf("Parse: %s", err)
+	}
+	if e != exp {
+		t.Errorf("expiration: expected %d, got %d", exp, e)
+	}
+}
+
+func TestParseAcceptsV1(t *testing.T) {
+	secret := []byte("secret key")
+	exp := time.Now().Unix() + 120
+
+	EmitV1 = true
+	c := New("bender", exp, secret)
+	EmitV1 = false
+
+	l, e, err := Parse(c, secret)
+	if err != nil {
+		t.Fatalf("Parse of v1 cookie: %s", err)
+	}
+	if l != "bender" || e != exp {
+		t.Errorf("expected (bender, %d), got (%s, %d)", exp, l, e)
+	}
+}